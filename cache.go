@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // CGO不要のSQLiteドライバ
+)
+
+// totalCommitsCacheTTL はリポジトリのコミット総数キャッシュの有効期間
+const totalCommitsCacheTTL = 6 * time.Hour
+
+// Cache はコミットSHA単位のLLM評価結果とリポジトリのコミット総数をSQLiteに永続化し、
+// 再実行時に同じコミットを重複してLLMへ送らないようにする。
+type Cache struct {
+	db *sql.DB
+}
+
+// CachedCommitScore はキャッシュに保存されたコミット単位の評価結果を保持する
+type CachedCommitScore struct {
+	TechnicalScore int
+	MessageScore   int
+}
+
+// defaultCachePath は -cache-path が指定されなかった場合に使うデフォルトのキャッシュファイルパスを返す
+func defaultCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".cache", "leaders-commit-ranking", "cache.db")
+	}
+	return filepath.Join(home, ".cache", "leaders-commit-ranking", "cache.db")
+}
+
+// NewCache は path のSQLiteデータベースを開き(存在しなければ作成し)、必要なテーブルを用意する
+func NewCache(path string) (*Cache, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory for %s: %w", path, err)
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database at %s: %w", path, err)
+	}
+	// analyzeReposConcurrently は複数ゴルーチンから同時にキャッシュを読み書きするため、
+	// コネクションを1本に制限してSQLiteの "database is locked" を避ける。
+	db.SetMaxOpenConns(1)
+	if err := initCacheSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Cache{db: db}, nil
+}
+
+func initCacheSchema(db *sql.DB) error {
+	_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS commit_scores (
+	repo_full_name TEXT NOT NULL,
+	sha            TEXT NOT NULL,
+	message        TEXT,
+	diff_snippet   TEXT,
+	tech_score     INTEGER NOT NULL,
+	msg_score      INTEGER NOT NULL,
+	llm_model      TEXT,
+	created_at     INTEGER NOT NULL,
+	PRIMARY KEY (repo_full_name, sha)
+);
+CREATE TABLE IF NOT EXISTS repo_commit_counts (
+	repo_full_name TEXT PRIMARY KEY,
+	total_commits  INTEGER NOT NULL,
+	created_at     INTEGER NOT NULL
+);
+`)
+	if err != nil {
+		return fmt.Errorf("failed to initialize cache schema: %w", err)
+	}
+	return nil
+}
+
+// Close はキャッシュのデータベース接続を閉じる
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// GetCommitScore は repoFullName/sha のキャッシュ済みスコアを返す。見つからなければ ok=false
+func (c *Cache) GetCommitScore(repoFullName, sha string) (score CachedCommitScore, ok bool, err error) {
+	row := c.db.QueryRow(`SELECT tech_score, msg_score FROM commit_scores WHERE repo_full_name = ? AND sha = ?`, repoFullName, sha)
+	if scanErr := row.Scan(&score.TechnicalScore, &score.MessageScore); scanErr != nil {
+		if scanErr == sql.ErrNoRows {
+			return CachedCommitScore{}, false, nil
+		}
+		return CachedCommitScore{}, false, fmt.Errorf("failed to query cache for %s@%s: %w", repoFullName, sha, scanErr)
+	}
+	return score, true, nil
+}
+
+// PutCommitScore は repoFullName/sha の評価結果をキャッシュに書き込む(既存エントリは上書き)
+func (c *Cache) PutCommitScore(repoFullName string, sc CommitInfo, score *EvalScore, modelName string) error {
+	_, err := c.db.Exec(`
+INSERT INTO commit_scores (repo_full_name, sha, message, diff_snippet, tech_score, msg_score, llm_model, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(repo_full_name, sha) DO UPDATE SET
+	message      = excluded.message,
+	diff_snippet = excluded.diff_snippet,
+	tech_score   = excluded.tech_score,
+	msg_score    = excluded.msg_score,
+	llm_model    = excluded.llm_model,
+	created_at   = excluded.created_at
+`, repoFullName, sc.SHA, sc.Message, sc.Diff, score.TechnicalSophistication, score.MessageAppropriateness, modelName, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry for %s@%s: %w", repoFullName, sc.SHA, err)
+	}
+	return nil
+}
+
+// GetTotalCommits は repoFullName のキャッシュ済みコミット総数を返す。TTLを過ぎていたら ok=false
+func (c *Cache) GetTotalCommits(repoFullName string, ttl time.Duration) (total int, ok bool, err error) {
+	var createdAt int64
+	row := c.db.QueryRow(`SELECT total_commits, created_at FROM repo_commit_counts WHERE repo_full_name = ?`, repoFullName)
+	if scanErr := row.Scan(&total, &createdAt); scanErr != nil {
+		if scanErr == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to query cached commit count for %s: %w", repoFullName, scanErr)
+	}
+	if time.Since(time.Unix(createdAt, 0)) > ttl {
+		return 0, false, nil
+	}
+	return total, true, nil
+}
+
+// PutTotalCommits は repoFullName のコミット総数をキャッシュに書き込む(既存エントリは上書き)
+func (c *Cache) PutTotalCommits(repoFullName string, total int) error {
+	_, err := c.db.Exec(`
+INSERT INTO repo_commit_counts (repo_full_name, total_commits, created_at)
+VALUES (?, ?, ?)
+ON CONFLICT(repo_full_name) DO UPDATE SET total_commits = excluded.total_commits, created_at = excluded.created_at
+`, repoFullName, total, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to write cached commit count for %s: %w", repoFullName, err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// EvalScore はLLMバックエンドによる評価結果を保持する構造体
+type EvalScore struct {
+	TechnicalSophistication int `json:"technical_sophistication"` // 1-10
+	MessageAppropriateness  int `json:"message_appropriateness"`  // 1-10
+}
+
+// Evaluator はコミット群を分析してリポジトリの技術力・コミットメッセージの質を評価するLLMバックエンドを抽象化する
+type Evaluator interface {
+	AnalyzeCommits(repoName string, totalCommitCount int, sampledCommits []CommitInfo) (*EvalScore, error)
+}
+
+// NewEvaluator は設定の -llm フラグに応じたEvaluator実装を生成する
+func NewEvaluator(cfg *Config) (Evaluator, error) {
+	switch cfg.LLM {
+	case "", "gemini":
+		apiKey := os.Getenv("GEMINI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY is required for -llm=gemini")
+		}
+		return NewGeminiClient(apiKey)
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY is required for -llm=openai")
+		}
+		baseURL := os.Getenv("OPENAI_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		return NewOpenAIClient(apiKey, baseURL), nil
+	case "anthropic":
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY is required for -llm=anthropic")
+		}
+		baseURL := os.Getenv("ANTHROPIC_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://api.anthropic.com"
+		}
+		return NewAnthropicClient(apiKey, baseURL), nil
+	case "ollama":
+		baseURL := os.Getenv("OLLAMA_BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		return NewOllamaClient(baseURL), nil
+	default:
+		return nil, fmt.Errorf("unsupported -llm value %q (expected \"gemini\", \"openai\", \"anthropic\" or \"ollama\")", cfg.LLM)
+	}
+}
+
+// buildAnalysisPrompt は全バックエンド共通のプロンプトを組み立てる
+func buildAnalysisPrompt(repoName string, totalCommitCount int, sampledCommits []CommitInfo) string {
+	var analysisContent strings.Builder
+	fmt.Fprintf(&analysisContent, "Repository: %s\n", repoName)
+	fmt.Fprintf(&analysisContent, "Total Commits in Repository: %d\n\n", totalCommitCount)
+	fmt.Fprintf(&analysisContent, "Analyzing %d randomly sampled commits:\n", len(sampledCommits))
+
+	for i, sc := range sampledCommits {
+		fmt.Fprintf(&analysisContent, "\n--- Sampled Commit %d ---\n", i+1)
+		fmt.Fprintf(&analysisContent, "Commit Message:\n%s\n\n", sc.Message)
+		fmt.Fprintf(&analysisContent, "Commit Diff (first 100 lines or less):\n%s\n", sc.Diff)
+	}
+
+	promptFormat := `
+You are an expert code reviewer. Analyze the provided commit data for the repository named '%s'.
+The data includes the total number of commits in the repository and a sample of %d individual commits, each with its commit message and a snippet of its diff (up to the first 100 lines).
+
+Based ONLY on the provided information for these sampled commits, evaluate the following:
+
+1.  **Technical Sophistication (1-10 points):**
+    From the diff snippets of the sampled commits, assess the complexity of the changes, the use of advanced techniques or technologies, and the ingenuity in problem-solving.
+    A score of 1 means very simple changes (e.g., typo fixes, minor documentation updates).
+    A score of 10 means highly complex changes involving significant architectural work, advanced algorithms, or novel technology applications.
+    If diffs are empty or uninformative, assign a low score.
+
+2.  **Commit Message Appropriateness (1-10 points):**
+    For each sampled commit, evaluate how well its commit message aligns with its corresponding diff snippet.
+    Does the message accurately and concisely describe what was changed in the diff?
+    A score of 1 means the message is irrelevant, misleading, or completely uninformative regarding the diff.
+    A score of 10 means the message perfectly and clearly describes the changes shown in the diff.
+    Consider the average appropriateness across all sampled commits.
+
+Please provide your evaluation STRICTLY in the following JSON format, with no other text before or after the JSON block:
+{
+  "technical_sophistication": <integer_score_1_to_10_for_overall_repo_based_on_samples>,
+  "message_appropriateness": <integer_score_1_to_10_for_average_message_quality_based_on_samples>
+}
+
+Analysis Data:
+%s
+`
+	return fmt.Sprintf(promptFormat, repoName, len(sampledCommits), analysisContent.String())
+}
+
+// defaultEvalScoreForEmptySample は総コミット数・サンプル件数が0の場合に使う既定スコアを返す
+// (LLMへの問い合わせ自体が不要なケースなので、全バックエンド共通で利用する)
+func defaultEvalScoreForEmptySample(repoName string, totalCommitCount int, sampledCommits []CommitInfo) *EvalScore {
+	if totalCommitCount == 0 {
+		return &EvalScore{TechnicalSophistication: 0, MessageAppropriateness: 0}
+	}
+	if len(sampledCommits) == 0 {
+		logger.Warn("no sampled commits to analyze, assigning low scores", "repo", repoName)
+		return &EvalScore{TechnicalSophistication: 1, MessageAppropriateness: 0}
+	}
+	return nil
+}
+
+// extractEvalScoreJSON はLLMの生レスポンスからJSON Markdownブロック、もしくは生のJSONオブジェクトを抽出してパースする。
+// 各バックエンドのレスポンス整形に差異があっても同じ抽出ロジックで対応できるようにする。
+func extractEvalScoreJSON(repoName, rawResponse string) (*EvalScore, error) {
+	re := regexp.MustCompile("(?s)```json\n(.*?)\n```")
+	matches := re.FindStringSubmatch(rawResponse)
+	extractedJSON := ""
+	if len(matches) > 1 {
+		extractedJSON = strings.TrimSpace(matches[1])
+	} else {
+		// If not in markdown, try to find JSON directly.
+		reJSON := regexp.MustCompile(`(?s)\s*\{\s*("technical_sophistication"|"message_appropriateness")[\s\S]*?\}\s*`)
+		foundJSON := reJSON.FindString(rawResponse)
+		if foundJSON != "" {
+			extractedJSON = strings.TrimSpace(foundJSON)
+		} else {
+			logger.Warn("could not find or extract JSON from LLM response", "repo", repoName, "raw", rawResponse)
+			return nil, fmt.Errorf("could not extract JSON from LLM response for %s. Raw: %s", repoName, rawResponse)
+		}
+	}
+
+	var score EvalScore
+	if err := json.Unmarshal([]byte(extractedJSON), &score); err != nil {
+		logger.Warn("failed to parse extracted LLM JSON response", "repo", repoName, "json", extractedJSON, "err", err)
+		return nil, fmt.Errorf("failed to parse extracted LLM JSON response for %s, content: '%s': %w", repoName, extractedJSON, err)
+	}
+	return &score, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AnthropicClient はAnthropicのMessages APIを呼び出し、Evaluatorインターフェースを満たす
+type AnthropicClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewAnthropicClient は新しいAnthropicクライアントを作成する
+func NewAnthropicClient(apiKey, baseURL string) *AnthropicClient {
+	return &AnthropicClient{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+type anthropicMessagesRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	Messages  []anthropicMessage `json:"messages"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicMessagesResponse struct {
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// AnalyzeCommits はコミット情報をAnthropicのMessages APIに送信して評価を取得
+func (ac *AnthropicClient) AnalyzeCommits(repoName string, totalCommitCount int, sampledCommits []CommitInfo) (*EvalScore, error) {
+	if score := defaultEvalScoreForEmptySample(repoName, totalCommitCount, sampledCommits); score != nil {
+		return score, nil
+	}
+
+	prompt := buildAnalysisPrompt(repoName, totalCommitCount, sampledCommits)
+
+	reqBody, err := json.Marshal(anthropicMessagesRequest{
+		Model:     "claude-3-5-sonnet-latest",
+		MaxTokens: 1024,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: prompt},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anthropic request for repo %s: %w", repoName, err)
+	}
+
+	var body []byte
+	err = withRetry(context.Background(), llmLimiter, "anthropic.AnalyzeCommits", []any{"repo", repoName}, func(attempt int) error {
+		req, reqErr := http.NewRequest(http.MethodPost, ac.baseURL+"/v1/messages", bytes.NewReader(reqBody))
+		if reqErr != nil {
+			return fmt.Errorf("failed to build anthropic request for repo %s: %w", repoName, reqErr)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", ac.apiKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+
+		resp, doErr := ac.httpClient.Do(req)
+		if doErr != nil {
+			return fmt.Errorf("failed to call anthropic for repo %s: %w", repoName, doErr)
+		}
+		defer resp.Body.Close()
+
+		respBody, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return fmt.Errorf("failed to read anthropic response for repo %s: %w", repoName, readErr)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("anthropic request for repo %s failed with status %d: %s", repoName, resp.StatusCode, string(respBody))
+		}
+		body = respBody
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var msgResp anthropicMessagesResponse
+	if err := json.Unmarshal(body, &msgResp); err != nil {
+		return nil, fmt.Errorf("failed to parse anthropic response for repo %s: %w", repoName, err)
+	}
+	if len(msgResp.Content) == 0 {
+		return nil, fmt.Errorf("anthropic response is empty for repo %s", repoName)
+	}
+
+	logger.Debug("anthropic raw response", "repo", repoName, "response", msgResp.Content[0].Text)
+
+	return extractEvalScoreJSON(repoName, msgResp.Content[0].Text)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+)
+
+// CachedEvaluator はEvaluatorをラップし、サンプルコミットのうちキャッシュ済みのものをLLMに
+// 再送せず、未キャッシュのコミットだけを個別に評価してキャッシュへ書き戻す。
+// 最終的なリポジトリスコアは、キャッシュ済み・新規評価済みの各コミットスコアの平均として算出する。
+type CachedEvaluator struct {
+	inner     Evaluator
+	cache     *Cache
+	modelName string // キャッシュの llm_model 列に書き込むラベル (-llm フラグの値)
+}
+
+// NewCachedEvaluator は inner をラップする CachedEvaluator を作成する
+func NewCachedEvaluator(inner Evaluator, cache *Cache, modelName string) *CachedEvaluator {
+	return &CachedEvaluator{inner: inner, cache: cache, modelName: modelName}
+}
+
+// AnalyzeCommits はキャッシュ済みのコミットをLLMに再送せず、未キャッシュのコミットだけを
+// まとめて1回のLLM呼び出しで評価する。未キャッシュ分のバッチスコアをそれぞれのSHAにキャッシュとして
+// 書き戻した上で、キャッシュ済みスコアと新規バッチスコアをコミット数で重み付け平均してリポジトリ全体の
+// EvalScoreを返す。
+func (ce *CachedEvaluator) AnalyzeCommits(repoName string, totalCommitCount int, sampledCommits []CommitInfo) (*EvalScore, error) {
+	if totalCommitCount == 0 || len(sampledCommits) == 0 {
+		return ce.inner.AnalyzeCommits(repoName, totalCommitCount, sampledCommits)
+	}
+
+	var cachedCommits, uncachedCommits []CommitInfo
+	var techSum, msgSum, scored int
+	for _, sc := range sampledCommits {
+		if cached, ok, err := ce.cache.GetCommitScore(repoName, sc.SHA); err == nil && ok {
+			cachedCommits = append(cachedCommits, sc)
+			techSum += cached.TechnicalScore
+			msgSum += cached.MessageScore
+			scored++
+			continue
+		}
+		uncachedCommits = append(uncachedCommits, sc)
+	}
+
+	if len(uncachedCommits) > 0 {
+		score, err := ce.inner.AnalyzeCommits(repoName, totalCommitCount, uncachedCommits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %d uncached commits for %s: %w", len(uncachedCommits), repoName, err)
+		}
+		for _, sc := range uncachedCommits {
+			if err := ce.cache.PutCommitScore(repoName, sc, score, ce.modelName); err != nil {
+				logger.Warn("failed to write cache entry", "repo", repoName, "sha", sc.SHA, "err", err)
+			}
+		}
+		techSum += score.TechnicalSophistication * len(uncachedCommits)
+		msgSum += score.MessageAppropriateness * len(uncachedCommits)
+		scored += len(uncachedCommits)
+	}
+
+	logger.Debug("cached evaluator coverage", "repo", repoName, "cached", len(cachedCommits), "uncached", len(uncachedCommits))
+
+	if scored == 0 {
+		return &EvalScore{}, nil
+	}
+	return &EvalScore{
+		TechnicalSophistication: techSum / scored,
+		MessageAppropriateness:  msgSum / scored,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai" // Gemini API client
+	"google.golang.org/api/option"             // For Gemini API client options
+)
+
+// GeminiClient はGemini APIのクライアントをラップし、Evaluatorインターフェースを満たす
+type GeminiClient struct {
+	client *genai.GenerativeModel
+	ctx    context.Context
+	apiKey string
+}
+
+// NewGeminiClient は新しいGeminiクライアントを作成
+func NewGeminiClient(apiKey string) (*GeminiClient, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY is required. Please set it as an environment variable")
+	}
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create genai client: %w", err)
+	}
+	// For text-only input, use a relevant model. gemini-1.5-flash-latest is a good general-purpose choice.
+	model := client.GenerativeModel("gemini-1.5-flash-latest")
+
+	return &GeminiClient{
+		client: model,
+		ctx:    ctx,
+		apiKey: apiKey,
+	}, nil
+}
+
+// AnalyzeCommits はコミット情報をGemini APIに送信して評価を取得
+func (gc *GeminiClient) AnalyzeCommits(repoName string, totalCommitCount int, sampledCommits []CommitInfo) (*EvalScore, error) {
+	if score := defaultEvalScoreForEmptySample(repoName, totalCommitCount, sampledCommits); score != nil {
+		return score, nil
+	}
+
+	prompt := buildAnalysisPrompt(repoName, totalCommitCount, sampledCommits)
+
+	logger.Debug("sending prompt to gemini", "repo", repoName, "total_commits", totalCommitCount, "sampled", len(sampledCommits), "prompt_len", len(prompt))
+	if len(prompt) > 30000 { // Gemini Pro has a 32k token limit, Flash has 1M, but be mindful
+		logger.Warn("gemini prompt is very long, may exceed API limits or be slow", "repo", repoName, "prompt_len", len(prompt))
+	}
+
+	var jsonResponse string
+	err := withRetry(gc.ctx, llmLimiter, "gemini.AnalyzeCommits", []any{"repo", repoName}, func(attempt int) error {
+		resp, genErr := gc.client.GenerateContent(gc.ctx, genai.Text(prompt))
+		if genErr != nil {
+			return fmt.Errorf("failed to generate content from gemini for repo %s: %w", repoName, genErr)
+		}
+
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+			return fmt.Errorf("gemini response is empty or invalid for repo %s", repoName)
+		}
+
+		textPart, ok := resp.Candidates[0].Content.Parts[0].(genai.Text)
+		if !ok {
+			return fmt.Errorf("unexpected response part type from gemini for repo %s: %T", repoName, resp.Candidates[0].Content.Parts[0])
+		}
+		jsonResponse = string(textPart)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("gemini raw response", "repo", repoName, "response", jsonResponse)
+
+	return extractEvalScoreJSON(repoName, jsonResponse)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaClient はローカルで動作するOllamaのHTTP APIを呼び出し、Evaluatorインターフェースを満たす。
+// ソースコードを外部クラウドに送信できないが、ローカルモデルなら運用できるチーム向けの選択肢。
+type OllamaClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOllamaClient は新しいOllamaクライアントを作成する
+func NewOllamaClient(baseURL string) *OllamaClient {
+	return &OllamaClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+type ollamaGenerateRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+type ollamaGenerateResponse struct {
+	Response string `json:"response"`
+}
+
+// AnalyzeCommits はコミット情報をOllamaの /api/generate エンドポイントに送信して評価を取得
+func (oc *OllamaClient) AnalyzeCommits(repoName string, totalCommitCount int, sampledCommits []CommitInfo) (*EvalScore, error) {
+	if score := defaultEvalScoreForEmptySample(repoName, totalCommitCount, sampledCommits); score != nil {
+		return score, nil
+	}
+
+	prompt := buildAnalysisPrompt(repoName, totalCommitCount, sampledCommits)
+
+	reqBody, err := json.Marshal(ollamaGenerateRequest{
+		Model:  "llama3.1",
+		Prompt: prompt,
+		Stream: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ollama request for repo %s: %w", repoName, err)
+	}
+
+	var body []byte
+	err = withRetry(context.Background(), llmLimiter, "ollama.AnalyzeCommits", []any{"repo", repoName}, func(attempt int) error {
+		req, reqErr := http.NewRequest(http.MethodPost, oc.baseURL+"/api/generate", bytes.NewReader(reqBody))
+		if reqErr != nil {
+			return fmt.Errorf("failed to build ollama request for repo %s: %w", repoName, reqErr)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, doErr := oc.httpClient.Do(req)
+		if doErr != nil {
+			return fmt.Errorf("failed to call ollama for repo %s: %w", repoName, doErr)
+		}
+		defer resp.Body.Close()
+
+		respBody, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return fmt.Errorf("failed to read ollama response for repo %s: %w", repoName, readErr)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("ollama request for repo %s failed with status %d: %s", repoName, resp.StatusCode, string(respBody))
+		}
+		body = respBody
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var genResp ollamaGenerateResponse
+	if err := json.Unmarshal(body, &genResp); err != nil {
+		return nil, fmt.Errorf("failed to parse ollama response for repo %s: %w", repoName, err)
+	}
+
+	logger.Debug("ollama raw response", "repo", repoName, "response", genResp.Response)
+
+	return extractEvalScoreJSON(repoName, genResp.Response)
+}
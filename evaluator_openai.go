@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient はOpenAIのChat Completions APIを呼び出し、Evaluatorインターフェースを満たす
+type OpenAIClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOpenAIClient は新しいOpenAIクライアントを作成する
+func NewOpenAIClient(apiKey, baseURL string) *OpenAIClient {
+	return &OpenAIClient{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+type openAIChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []openAIChatMessage `json:"messages"`
+}
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIChatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// AnalyzeCommits はコミット情報をOpenAIのChat Completions APIに送信して評価を取得
+func (oc *OpenAIClient) AnalyzeCommits(repoName string, totalCommitCount int, sampledCommits []CommitInfo) (*EvalScore, error) {
+	if score := defaultEvalScoreForEmptySample(repoName, totalCommitCount, sampledCommits); score != nil {
+		return score, nil
+	}
+
+	prompt := buildAnalysisPrompt(repoName, totalCommitCount, sampledCommits)
+
+	reqBody, err := json.Marshal(openAIChatRequest{
+		Model: "gpt-4o-mini",
+		Messages: []openAIChatMessage{
+			{Role: "user", Content: prompt},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal openai request for repo %s: %w", repoName, err)
+	}
+
+	var body []byte
+	err = withRetry(context.Background(), llmLimiter, "openai.AnalyzeCommits", []any{"repo", repoName}, func(attempt int) error {
+		req, reqErr := http.NewRequest(http.MethodPost, oc.baseURL+"/chat/completions", bytes.NewReader(reqBody))
+		if reqErr != nil {
+			return fmt.Errorf("failed to build openai request for repo %s: %w", repoName, reqErr)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+oc.apiKey)
+
+		resp, doErr := oc.httpClient.Do(req)
+		if doErr != nil {
+			return fmt.Errorf("failed to call openai for repo %s: %w", repoName, doErr)
+		}
+		defer resp.Body.Close()
+
+		respBody, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return fmt.Errorf("failed to read openai response for repo %s: %w", repoName, readErr)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("openai request for repo %s failed with status %d: %s", repoName, resp.StatusCode, string(respBody))
+		}
+		body = respBody
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to parse openai response for repo %s: %w", repoName, err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("openai response is empty for repo %s", repoName)
+	}
+
+	logger.Debug("openai raw response", "repo", repoName, "response", chatResp.Choices[0].Message.Content)
+
+	return extractEvalScoreJSON(repoName, chatResp.Choices[0].Message.Content)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	ghttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+const blameTopNFiles = 20 // -by-author でblame対象とする最大サイズのソースファイル数
+
+// LocalGitAnalyzer はリポジトリをテンポラリディレクトリにクローンし、go-gitで
+// コミット履歴を辿ることで、GitHub/Gitea APIへのN×コミット分のリクエストを発生させずに
+// コミットの分析データ(と、必要であれば著者別の行数統計)を取得する。
+// 大規模なOrganizationではAPIのレート制限を回避しつつ、分析を高速化できる。
+// totalCommits/-by-author の算出に全履歴が必要なため、クローン自体は浅くしていない。
+type LocalGitAnalyzer struct{}
+
+// NewLocalGitAnalyzer は新しいLocalGitAnalyzerを作成する
+func NewLocalGitAnalyzer() *LocalGitAnalyzer {
+	return &LocalGitAnalyzer{}
+}
+
+// Analyze は cloneURL のリポジトリをクローンし、コミット総数、ランダムに選んだ
+// コミットの詳細、および collectAuthorStats が true の場合は上位 blameTopNFiles 個の
+// ソースファイルに対する著者別の行数統計を取得する。
+func (la *LocalGitAnalyzer) Analyze(cloneURL, repoName string, auth transport.AuthMethod, numRandomCommitsToAnalyze, diffLinesLimit int, collectAuthorStats bool) (totalCommits int, analyzedCommits []CommitInfo, authorScores []AuthorScore, err error) {
+	tmpDir, err := os.MkdirTemp("", "leaders-commit-ranking-*")
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to create tempdir for %s: %w", repoName, err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// totalCommits はHEADからの全コミット履歴を辿って数えており(下のrepo.Log)、-by-author の
+	// BlameTopFiles も各行の最終変更者をたどるために全履歴を必要とするため、ここは意図的に
+	// 浅いクローンにしていない。Depthを付けると両方の値が不正確になってしまう。
+	repo, err := git.PlainClone(tmpDir, false, &git.CloneOptions{URL: cloneURL, Auth: auth})
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to clone %s: %w", repoName, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to resolve HEAD for %s: %w", repoName, err)
+	}
+
+	commitIter, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to walk commit log for %s: %w", repoName, err)
+	}
+
+	shas := make([]string, 0)
+	bySHA := make(map[string]*object.Commit)
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		sha := c.Hash.String()
+		shas = append(shas, sha)
+		bySHA[sha] = c
+		return nil
+	})
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to iterate commit log for %s: %w", repoName, err)
+	}
+	totalCommits = len(shas)
+	if totalCommits == 0 {
+		return 0, []CommitInfo{}, nil, nil
+	}
+
+	for _, sha := range selectNRandomSHAs(shas, numRandomCommitsToAnalyze) {
+		c := bySHA[sha]
+		diff, diffErr := diffForCommit(c, diffLinesLimit)
+		if diffErr != nil {
+			logger.Warn("failed to compute diff, proceeding with no diff", "repo", repoName, "sha", sha, "err", diffErr)
+		}
+		analyzedCommits = append(analyzedCommits, CommitInfo{
+			SHA:     sha,
+			Message: c.Message,
+			Diff:    diff,
+			// go-gitはGPG鍵の検証までは行わないため、署名の有無のみをシグナルとして扱う
+			Verified:        c.PGPSignature != "",
+			SignatureReason: signatureReasonFromPresence(c.PGPSignature != ""),
+		})
+	}
+
+	if collectAuthorStats {
+		authorScores, err = BlameTopFiles(repo, head.Hash(), blameTopNFiles)
+		if err != nil {
+			logger.Warn("failed to compute author blame stats, skipping author stats for this repo", "repo", repoName, "err", err)
+			authorScores = nil
+			err = nil
+		}
+	}
+
+	return totalCommits, analyzedCommits, authorScores, nil
+}
+
+func signatureReasonFromPresence(present bool) string {
+	if present {
+		return "signed"
+	}
+	return "unsigned"
+}
+
+// diffForCommit は c とその親とのツリー差分を計算し、diffLinesLimit 行に切り詰めて返す
+func diffForCommit(c *object.Commit, diffLinesLimit int) (string, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return "", err
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return "", err
+		}
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return "", err
+	}
+	patch, err := changes.Patch()
+	if err != nil {
+		return "", err
+	}
+	return truncatePatch(patch.String(), diffLinesLimit), nil
+}
+
+func truncatePatch(patch string, diffLinesLimit int) string {
+	if diffLinesLimit <= 0 {
+		return patch
+	}
+	lines := strings.Split(patch, "\n")
+	if len(lines) <= diffLinesLimit {
+		return patch
+	}
+	return strings.Join(lines[:diffLinesLimit], "\n") + "\n... (diff truncated due to line limit)\n"
+}
+
+// cloneURLForRepo は -mode=local で使用するクローンURLを、選択中のSCMバックエンドに応じて構築する
+func cloneURLForRepo(cfg *Config, repoName string) string {
+	if cfg.SCM == "gitea" {
+		return fmt.Sprintf("%s/%s/%s.git", strings.TrimSuffix(cfg.BaseURL, "/"), cfg.Organization, repoName)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s.git", cfg.Organization, repoName)
+}
+
+// cloneAuthForConfig は -mode=local でのクローンに使う認証情報を cfg.Token から組み立てる。
+// GitHub/Gitea双方ともHTTPS経由のクローンはトークンをパスワードとしたBasic認証を受け付けるため、
+// scm_github.go/scm_gitea.go がAPI呼び出しに使っているのと同じトークンをここでも使い回す。
+// トークンが設定されていなければ認証なし(公開リポジトリのみクローン可能)で動作する。
+func cloneAuthForConfig(cfg *Config) transport.AuthMethod {
+	if cfg.Token == "" {
+		return nil
+	}
+	username := "x-access-token"
+	if cfg.SCM == "gitea" {
+		username = "oauth2"
+	}
+	return &ghttp.BasicAuth{Username: username, Password: cfg.Token}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// logger はアプリケーション全体で使う構造化ロガー。main() の冒頭で -log-level/-log-format
+// を元に setupLogger で上書きされるまでは slog.Default() を使う。
+var logger = slog.Default()
+
+// setupLogger は -log-level/-log-format の値から slog.Logger を構築する
+func setupLogger(level, format string) *slog.Logger {
+	var slogLevel slog.Level
+	switch level {
+	case "debug":
+		slogLevel = slog.LevelDebug
+	case "warn":
+		slogLevel = slog.LevelWarn
+	case "error":
+		slogLevel = slog.LevelError
+	default:
+		slogLevel = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: slogLevel}
+	var handler slog.Handler
+	if format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+	return slog.New(handler)
+}
@@ -1,44 +1,31 @@
 package main
 
 import (
-	"context"
-	"crypto/rand"   // For random commit selection
-	"encoding/json" // For Gemini API response parsing
 	"flag"
 	"fmt"
-	"log"
-	"math/big" // For random commit selection
 	"os"
-	"regexp" // For parsing Gemini response
-	"sort"   // For sorting final scores
+	"sort" // For sorting final scores
 	"strings"
+	"sync"
 	// "time" // No longer explicitly used after removing rand.Seed
-
-	"github.com/google/generative-ai-go/genai" // Gemini API client
-	"github.com/google/go-github/v57/github"
-	"golang.org/x/oauth2"
-	"google.golang.org/api/option" // For Gemini API client options
 )
 
 // CommitInfo は分析対象のコミット詳細を保持
 type CommitInfo struct {
-	SHA     string
-	Message string
-	Diff    string // 先頭N行のdiff
-}
-
-// GeminiScore はGemini APIからの評価結果を保持する構造体
-type GeminiScore struct {
-	TechnicalSophistication int `json:"technical_sophistication"` // 1-10
-	MessageAppropriateness  int `json:"message_appropriateness"`  // 1-10
+	SHA             string
+	Message         string
+	Diff            string // 先頭N行のdiff
+	Verified        bool   // GPG/SSH署名が検証済みかどうか
+	SignatureReason string // 署名が未検証の場合の理由など(例: "unsigned", "valid", "bad_email")
 }
 
 // RepoScore はリポジトリの評価情報を保持する構造体
 type RepoScore struct {
 	Name                 string
 	CommitCount          int
-	TechnicalScore       int          // Geminiからの評価
-	MessageScore         int          // Geminiからの評価
+	TechnicalScore       int          // Evaluatorからの評価
+	MessageScore         int          // Evaluatorからの評価
+	SignatureRatio       float64      // サンプル内で署名検証済みだったコミットの割合 (0-1)
 	OverallScore         float64      // 加重平均などで計算
 	AnalyzedCommitsCount int          // 分析対象となったコミット数
 	SampledCommits       []CommitInfo // 分析に使用したコミットのサンプル
@@ -55,542 +42,292 @@ func min(a, b int) int {
 type Config struct {
 	Organization string
 	Token        string
+	SCM          string // "github" (default) or "gitea"
+	BaseURL      string // self-hosted instance URL, required when SCM is "gitea"
+	Mode         string // "api" (default) or "local"
+	ByAuthor     bool   // in -mode=local, also emit a per-author leaderboard via git blame
+	LLM          string // "gemini" (default), "openai", "anthropic" or "ollama"
+	NoCache      bool   // disable the SQLite commit-score cache entirely
+	CachePath    string // path to the SQLite cache database
+	Concurrency  int    // number of repositories analyzed in parallel
+	LogLevel     string // "debug", "info" (default), "warn" or "error"
+	LogFormat    string // "text" (default) or "json"
 }
 
-// GitHubClient はGitHub APIのクライアントをラップする構造体
-type GitHubClient struct {
-	client *github.Client
-	ctx    context.Context
-}
+func parseFlags() *Config {
+	orgName := flag.String("org", "", "Organization (or Gitea/Forgejo owner) name (required)")
+	token := flag.String("token", os.Getenv("GITHUB_TOKEN"), "Access token (optional, defaults to GITHUB_TOKEN env var)")
+	scm := flag.String("scm", "github", "SCM backend to use: \"github\" or \"gitea\"")
+	baseURL := flag.String("base-url", "", "Base URL of the self-hosted instance (required when -scm=gitea)")
+	mode := flag.String("mode", "api", "Commit analysis mode: \"api\" (per-commit REST calls) or \"local\" (clone + go-git)")
+	byAuthor := flag.Bool("by-author", false, "In -mode=local, also emit a per-author leaderboard based on git blame of the largest source files")
+	llm := flag.String("llm", "gemini", "LLM backend to use: \"gemini\", \"openai\", \"anthropic\" or \"ollama\"")
+	noCache := flag.Bool("no-cache", false, "Disable the SQLite commit-score cache")
+	cachePath := flag.String("cache-path", defaultCachePath(), "Path to the SQLite cache database")
+	concurrency := flag.Int("concurrency", 4, "Number of repositories to analyze in parallel")
+	logLevel := flag.String("log-level", "info", "Log level: \"debug\", \"info\", \"warn\" or \"error\"")
+	logFormat := flag.String("log-format", "text", "Log output format: \"text\" or \"json\"")
 
-// GeminiClient はGemini APIのクライアントをラップする構造体
-type GeminiClient struct {
-	client *genai.GenerativeModel
-	ctx    context.Context
-	apiKey string
-}
+	flag.Parse()
 
-// NewGitHubClient は新しいGitHubクライアントを作成
-func NewGitHubClient(token string) *GitHubClient {
-	ctx := context.Background()
-	var ghClient *github.Client
-
-	if token != "" {
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-		ghClient = github.NewClient(tc)
-	} else {
-		ghClient = github.NewClient(nil)
+	if *orgName == "" {
+		logger.Error("organization name is required")
+		flag.Usage()
+		os.Exit(1)
 	}
 
-	return &GitHubClient{
-		client: ghClient,
-		ctx:    ctx,
+	return &Config{
+		Organization: *orgName,
+		Token:        *token,
+		SCM:          *scm,
+		BaseURL:      *baseURL,
+		Mode:         *mode,
+		ByAuthor:     *byAuthor,
+		LLM:          *llm,
+		NoCache:      *noCache,
+		CachePath:    *cachePath,
+		Concurrency:  *concurrency,
+		LogLevel:     *logLevel,
+		LogFormat:    *logFormat,
 	}
 }
 
-// NewGeminiClient は新しいGeminiクライアントを作成
-func NewGeminiClient(apiKey string) (*GeminiClient, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY is required. Please set it as an environment variable")
-	}
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create genai client: %w", err)
-	}
-	// For text-only input, use a relevant model. gemini-1.5-flash-latest is a good general-purpose choice.
-	model := client.GenerativeModel("gemini-1.5-flash-latest")
-	// Consider setting temperature for more deterministic responses if needed
-	// model.SafetySettings = []*genai.SafetySetting{
-	// 	{
-	// 		Category:  genai.HarmCategoryHarassment,
-	// 		Threshold: genai.HarmBlockNone,
-	// 	},
-	// 	{
-	// 		Category:  genai.HarmCategoryHateSpeech,
-	// 		Threshold: genai.HarmBlockNone,
-	// 	},
-	// }
-
-	return &GeminiClient{
-		client: model,
-		ctx:    ctx,
-		apiKey: apiKey,
-	}, nil
-}
+const (
+	numRandomCommitsToAnalyze = 5   // Number of random commits to analyze per repo
+	diffLinesLimit            = 100 // Max lines of diff per commit file for analysis
+	// Weights for overall score calculation
+	commitCountWeight     = 0.2
+	technicalScoreWeight  = 0.3
+	messageScoreWeight    = 0.3
+	signatureScoreWeight  = 0.2
+	maxCommitCountForNorm = 1000 // For normalizing commit count score (cap at this)
+)
 
-// AnalyzeCommitsWithGemini はコミット情報をGemini APIに送信して評価を取得
-func (gc *GeminiClient) AnalyzeCommitsWithGemini(repoName string, totalCommitCount int, sampledCommits []CommitInfo) (*GeminiScore, error) {
-	if totalCommitCount == 0 {
-		return &GeminiScore{TechnicalSophistication: 0, MessageAppropriateness: 0}, nil
-	}
+// signatureRatio はサンプル内で署名検証済みだったコミットの割合(0-1)を計算する
+func signatureRatio(sampledCommits []CommitInfo) float64 {
 	if len(sampledCommits) == 0 {
-		// コミットはあるが分析対象のサンプルがない場合 (SHA取得失敗など)
-		// 技術点は低め、メッセージ適切性は0とする
-		log.Printf("No sampled commits to analyze for %s, assigning low scores.", repoName)
-		return &GeminiScore{TechnicalSophistication: 1, MessageAppropriateness: 0}, nil
+		return 0
 	}
-
-	var analysisContent strings.Builder
-	fmt.Fprintf(&analysisContent, "Repository: %s\n", repoName)
-	fmt.Fprintf(&analysisContent, "Total Commits in Repository: %d\n\n", totalCommitCount)
-	fmt.Fprintf(&analysisContent, "Analyzing %d randomly sampled commits:\n", len(sampledCommits))
-
-	for i, sc := range sampledCommits {
-		fmt.Fprintf(&analysisContent, "\n--- Sampled Commit %d ---\n", i+1)
-		fmt.Fprintf(&analysisContent, "Commit Message:\n%s\n\n", sc.Message)
-		fmt.Fprintf(&analysisContent, "Commit Diff (first 100 lines or less):\n%s\n", sc.Diff)
+	var verified int
+	for _, sc := range sampledCommits {
+		if sc.Verified {
+			verified++
+		}
 	}
-
-	promptFormat := `
-You are an expert code reviewer. Analyze the provided commit data for the repository named '%s'.
-The data includes the total number of commits in the repository and a sample of %d individual commits, each with its commit message and a snippet of its diff (up to the first 100 lines).
-
-Based ONLY on the provided information for these sampled commits, evaluate the following:
-
-1.  **Technical Sophistication (1-10 points):**
-    From the diff snippets of the sampled commits, assess the complexity of the changes, the use of advanced techniques or technologies, and the ingenuity in problem-solving.
-    A score of 1 means very simple changes (e.g., typo fixes, minor documentation updates).
-    A score of 10 means highly complex changes involving significant architectural work, advanced algorithms, or novel technology applications.
-    If diffs are empty or uninformative, assign a low score.
-
-2.  **Commit Message Appropriateness (1-10 points):**
-    For each sampled commit, evaluate how well its commit message aligns with its corresponding diff snippet.
-    Does the message accurately and concisely describe what was changed in the diff?
-    A score of 1 means the message is irrelevant, misleading, or completely uninformative regarding the diff.
-    A score of 10 means the message perfectly and clearly describes the changes shown in the diff.
-    Consider the average appropriateness across all sampled commits.
-
-Please provide your evaluation STRICTLY in the following JSON format, with no other text before or after the JSON block:
-{
-  "technical_sophistication": <integer_score_1_to_10_for_overall_repo_based_on_samples>,
-  "message_appropriateness": <integer_score_1_to_10_for_average_message_quality_based_on_samples>
+	return float64(verified) / float64(len(sampledCommits))
 }
 
-Analysis Data:
-%s
-`
-	prompt := fmt.Sprintf(promptFormat, repoName, len(sampledCommits), analysisContent.String())
-
-	// Log a snippet of the prompt for debugging
-	log.Printf("Sending prompt to Gemini for repo %s (Total commits: %d, Sampled: %d). Prompt length: %d chars.\n", repoName, totalCommitCount, len(sampledCommits), len(prompt))
-	if len(prompt) > 30000 { // Gemini Pro has a 32k token limit, Flash has 1M, but be mindful
-		log.Printf("Warning: Prompt for %s is very long (%d chars), may exceed API limits or be slow.", repoName, len(prompt))
-	}
+func main() {
+	cfg := parseFlags()
+	logger = setupLogger(cfg.LogLevel, cfg.LogFormat)
 
-	resp, err := gc.client.GenerateContent(gc.ctx, genai.Text(prompt))
+	scmClient, err := NewSCMClient(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate content from gemini for repo %s: %w", repoName, err)
-	}
-
-	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("gemini response is empty or invalid for repo %s", repoName)
-	}
-
-	jsonResponse := ""
-	if textPart, ok := resp.Candidates[0].Content.Parts[0].(genai.Text); ok {
-		jsonResponse = string(textPart)
-	} else {
-		return nil, fmt.Errorf("unexpected response part type from gemini for repo %s: %T", repoName, resp.Candidates[0].Content.Parts[0])
-	}
-
-	log.Printf("Gemini raw response for %s: %s\n", repoName, jsonResponse)
-
-	// Extract JSON from markdown code block if present, or directly if not in markdown
-	re := regexp.MustCompile("(?s)```json\n(.*?)\n```")
-	matches := re.FindStringSubmatch(jsonResponse)
-	extractedJSON := ""
-	if len(matches) > 1 {
-		extractedJSON = strings.TrimSpace(matches[1])
-	} else {
-		// If not in markdown, try to find JSON directly.
-		// This handles cases where Gemini might return plain JSON.
-		// A more robust regex to find a JSON object.
-		reJSON := regexp.MustCompile(`(?s)\s*\{\s*("technical_sophistication"|"message_appropriateness")[\s\S]*?\}\s*`)
-		foundJSON := reJSON.FindString(jsonResponse)
-		if foundJSON != "" {
-			extractedJSON = strings.TrimSpace(foundJSON)
-		} else {
-			log.Printf("Could not find or extract JSON from Gemini response for %s. Raw: %s", repoName, jsonResponse)
-			return nil, fmt.Errorf("could not extract JSON from Gemini response for %s. Raw: %s", repoName, jsonResponse)
-		}
-	}
-
-	var score GeminiScore
-	if err := json.Unmarshal([]byte(extractedJSON), &score); err != nil {
-		log.Printf("Failed to parse extracted Gemini JSON response for %s. JSON attempted: '%s', Error: %v", repoName, extractedJSON, err)
-		return nil, fmt.Errorf("failed to parse extracted gemini JSON response for %s, content: '%s': %w", repoName, extractedJSON, err)
+		logger.Error("failed to create SCM client", "err", err)
+		os.Exit(1)
 	}
-
-	return &score, nil
-}
-
-// GetOrganizationRepos は指定されたOrganizationのリポジトリ一覧を取得
-func (ghc *GitHubClient) GetOrganizationRepos(orgName string) ([]*github.Repository, error) {
-	var allRepos []*github.Repository
-	opt := &github.RepositoryListByOrgOptions{
-		ListOptions: github.ListOptions{PerPage: 100},
+	evaluator, err := NewEvaluator(cfg)
+	if err != nil {
+		logger.Error("failed to create LLM evaluator", "err", err)
+		os.Exit(1)
 	}
 
-	for {
-		repos, resp, err := ghc.client.Repositories.ListByOrg(ghc.ctx, orgName, opt)
+	var cache *Cache
+	if !cfg.NoCache {
+		cache, err = NewCache(cfg.CachePath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list repositories for organization %s: %w", orgName, err)
+			logger.Error("failed to open commit-score cache", "err", err)
+			os.Exit(1)
 		}
-		allRepos = append(allRepos, repos...)
-		if resp.NextPage == 0 {
-			break
-		}
-		opt.Page = resp.NextPage
+		defer cache.Close()
+		evaluator = NewCachedEvaluator(evaluator, cache, cfg.LLM)
 	}
-	return allRepos, nil
-}
 
-// GetRepositoryCommitCount は指定されたリポジトリのコミット数を取得
-// 注意: この関数はデフォルトブランチのコミット数を取得しようとします。
-// GitHub API v3では、全ブランチの合計コミット数を直接取得する簡単な方法は提供されていません。
-// ここでは、リポジトリのコミットリストAPIを利用し、最初のコミットと最後のコミットの情報を取得することで、
-// おおよそのコミット数を推定するか、ページネーションを利用して全コミットを数えます。
-// より正確な数を取得するには、すべてのコミットをページネーションで取得する必要がありますが、
-// 大規模なリポジトリではAPIレート制限に達する可能性があります。
-// ここでは簡略化のため、コミットリストの最初のページの情報を利用します。
-// より堅牢な実装では、`ListCommits`のページネーションを処理する必要があります。
-func (ghc *GitHubClient) GetRepositoryCommitCount(owner, repoName string) (int, error) {
-	opt := &github.CommitsListOptions{
-		ListOptions: github.ListOptions{PerPage: 1}, // 最初の1件だけ取得してヘッダーを見る
-	}
-
-	// HEADを指定してデフォルトブランチのコミットを取得
-	commits, resp, err := ghc.client.Repositories.ListCommits(ghc.ctx, owner, repoName, opt)
+	logger.Info("fetching repositories", "org", cfg.Organization)
+	repoNames, err := scmClient.ListRepos(cfg.Organization)
 	if err != nil {
-		// リポジトリが空の場合など
-		if resp != nil && resp.StatusCode == 409 {
-			return 0, nil // 空のリポジトリはコミット0
-		}
-		return 0, fmt.Errorf("failed to list commits for %s/%s: %w", owner, repoName, err)
-	}
-
-	if len(commits) == 0 {
-		return 0, nil // コミットがない場合
+		logger.Error("failed to list repositories", "org", cfg.Organization, "err", err)
+		os.Exit(1)
 	}
 
-	// Linkヘッダーから最後のページ番号を取得して総コミット数を推定
-	// 例: <https://api.github.com/repositories/123/commits?page=2>; rel="next", <https://api.github.com/repositories/123/commits?page=34>; rel="last"
-	if resp.LastPage > 0 {
-		// 1ページあたりのコミット数はAPIによって異なる場合があるが、ここではListOptions.PerPageで指定した値(1)ではなく、
-		// GitHubのデフォルトのper_page (通常30) で計算されることが多い。
-		// より正確には、実際に全ページを取得する必要がある。
-		// ここでは簡略化のため、LastPage * (デフォルトのper_page) とする。
-		// ただし、ListCommitsのデフォルトは30件なので、それで計算する。
-		// もしPerParge=1でLastPageが得られるなら、それが総数に近い。
-		// しかし、通常はPerParge=1でLastPageは得られないか、得られても1になる。
-		// そのため、全件取得するロジックに切り替える。
-		return ghc.countAllCommits(owner, repoName)
+	if len(repoNames) == 0 {
+		logger.Info("no repositories found", "org", cfg.Organization)
+		return
 	}
 
-	// Linkヘッダーがない場合 (コミットが1ページに収まる場合)
-	return ghc.countAllCommits(owner, repoName)
-}
+	logger.Info("analyzing repositories", "count", len(repoNames), "concurrency", cfg.Concurrency)
 
-func (ghc *GitHubClient) countAllCommits(owner, repoName string) (int, error) {
-	var commitCount int
-	opt := &github.CommitsListOptions{
-		ListOptions: github.ListOptions{PerPage: 100},
-	}
-	for {
-		commits, resp, err := ghc.client.Repositories.ListCommits(ghc.ctx, owner, repoName, opt)
-		if err != nil {
-			if resp != nil && resp.StatusCode == 409 { // Conflict, e.g. empty repository
-				return 0, nil
-			}
-			return 0, fmt.Errorf("failed to list commits for %s/%s during full count: %w", owner, repoName, err)
-		}
-		commitCount += len(commits)
-		if resp.NextPage == 0 {
-			break
-		}
-		opt.Page = resp.NextPage
+	var localAnalyzer *LocalGitAnalyzer
+	if cfg.Mode == "local" {
+		localAnalyzer = NewLocalGitAnalyzer()
 	}
-	return commitCount, nil
-}
 
-// GetRepositoryCommitAnalysisData fetches total commit count and details of N random commits (message + diff snippet).
-// numRandomCommitsToAnalyze: 収集するランダムコミットの数
-// diffLinesLimit: 各コミットのdiffから取得する最大行数
-func (ghc *GitHubClient) GetRepositoryCommitAnalysisData(owner, repoName string, numRandomCommitsToAnalyze int, diffLinesLimit int) (totalCommits int, analyzedCommits []CommitInfo, err error) {
-	// Go 1.20未満の場合や明示的なシードが必要な場合
-	// rand.Seed(time.Now().UnixNano()) // crypto/rand を使うので不要
+	repoScores, authorLineCounts := analyzeReposConcurrently(cfg, scmClient, evaluator, cache, localAnalyzer, repoNames)
 
-	// 1. Get total commit count
-	totalCommits, err = ghc.countAllCommits(owner, repoName)
-	if err != nil {
-		// countAllCommitsが409でエラーなく0を返す場合があるので、それを考慮
-		if err.Error() == fmt.Sprintf("failed to list commits for %s/%s during full count: GET https://api.github.com/repos/%s/%s/commits?per_page=100: 409  []", owner, repoName, owner, repoName) && totalCommits == 0 {
-			// This specific error for empty repo means 0 commits, not a failure to list.
-		} else if totalCommits == 0 && strings.Contains(err.Error(), "409") { // More general 409 check
-			// Assume 0 commits if 409 and count is 0
-		} else {
-			return 0, nil, fmt.Errorf("error counting all commits for %s/%s: %w", owner, repoName, err)
-		}
-	}
-	if totalCommits == 0 {
-		return 0, []CommitInfo{}, nil
-	}
+	// Sort repositories by OverallScore in descending order
+	sort.SliceStable(repoScores, func(i, j int) bool {
+		return repoScores[i].OverallScore > repoScores[j].OverallScore
+	})
 
-	// 2. Get all commit SHAs (can be slow for very large repos)
-	var allCommitSHAs []string
-	listOpt := &github.CommitsListOptions{
-		ListOptions: github.ListOptions{PerPage: 100}, // Fetch 100 SHAs per page
-	}
-	for {
-		commitsPage, resp, listErr := ghc.client.Repositories.ListCommits(ghc.ctx, owner, repoName, listOpt)
-		if listErr != nil {
-			// If we fail to list commits here, but got a total count, return count with empty analysis.
-			// This could happen if repo becomes empty between count and list, or other issues.
-			log.Printf("Warning: Failed to list commit SHAs for %s/%s after getting count %d: %v. Proceeding with no sampled commits.", owner, repoName, totalCommits, listErr)
-			return totalCommits, []CommitInfo{}, nil
-		}
-		for _, c := range commitsPage {
-			if c.SHA != nil {
-				allCommitSHAs = append(allCommitSHAs, *c.SHA)
-			}
-		}
-		if resp.NextPage == 0 || len(allCommitSHAs) >= totalCommits { // Stop if no more pages or we have enough SHAs
-			break
-		}
-		listOpt.Page = resp.NextPage
-		if len(allCommitSHAs) > 500 && numRandomCommitsToAnalyze <= 10 { // Optimization: if repo is huge, don't fetch all SHAs if we only need a few
-			log.Printf("Optimization: Fetched %d SHAs for %s/%s, stopping early as we only need %d samples.", len(allCommitSHAs), owner, repoName, numRandomCommitsToAnalyze)
-			break
+	// Print results
+	fmt.Println(strings.Repeat("=", 120))
+	fmt.Printf("%-40s | %-10s | %-10s | %-10s | %-10s | %-10s | %-15s\n", "Repository", "Commits", "Tech Score", "Msg Score", "Signed %", "Overall", "Analyzed Smpls")
+	fmt.Println(strings.Repeat("-", 120))
+	for _, rs := range repoScores {
+		if rs.CommitCount == -1 { // Error case
+			fmt.Printf("%-40s | %-10s | %-10s | %-10s | %-10s | %-10s | %-15s\n", rs.Name, "ERROR", "N/A", "N/A", "N/A", "N/A", "N/A")
+			continue
 		}
+		fmt.Printf("%-40s | %-10d | %-10d | %-10d | %-10.0f | %-10.2f | %-15d\n",
+			rs.Name, rs.CommitCount, rs.TechnicalScore, rs.MessageScore, rs.SignatureRatio*100, rs.OverallScore, rs.AnalyzedCommitsCount)
 	}
+	fmt.Println(strings.Repeat("=", 120))
 
-	if len(allCommitSHAs) == 0 {
-		log.Printf("Warning: No commit SHAs found for %s/%s despite totalCommits = %d. Proceeding with no sampled commits.", owner, repoName, totalCommits)
-		return totalCommits, []CommitInfo{}, nil
+	if cfg.ByAuthor {
+		printAuthorLeaderboard(authorLineCounts)
 	}
 
-	// 3. Select N random unique commit SHAs
-	// selectedSHAsMap := make(map[string]bool) // No longer needed with Fisher-Yates
-	var finalSelectedSHAs []string
+	// Optional: Print details of sampled commits for top N repositories
+	// printTopNRepoSamples(repoScores, 3)
+}
 
-	numToSelect := numRandomCommitsToAnalyze
-	if len(allCommitSHAs) < numRandomCommitsToAnalyze {
-		numToSelect = len(allCommitSHAs) // Cannot select more than available
-	}
+// analyzeReposConcurrently は repoNames を cfg.Concurrency 個のワーカーで並行に分析する。
+// 結果は repoNames と同じ順序のスライスに格納してから返すため、以降のソート処理は並行度に関わらず決定的になる。
+func analyzeReposConcurrently(cfg *Config, scmClient SCMClient, evaluator Evaluator, cache *Cache, localAnalyzer *LocalGitAnalyzer, repoNames []string) ([]RepoScore, map[string]int) {
+	results := make([]RepoScore, len(repoNames))
 
-	// Fisher-Yates shuffle variant for selecting N random unique elements
-	// Create a slice of indices
-	indices := make([]int, len(allCommitSHAs))
-	for i := range indices {
-		indices[i] = i
-	}
-	// Shuffle the indices
-	for i := len(indices) - 1; i > 0; i-- {
-		jBig, _ := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
-		j := int(jBig.Int64())
-		indices[i], indices[j] = indices[j], indices[i]
-	}
-	// Take the first numToSelect shuffled indices to get random SHAs
-	for i := 0; i < numToSelect; i++ {
-		finalSelectedSHAs = append(finalSelectedSHAs, allCommitSHAs[indices[i]])
+	authorLineCounts := make(map[string]int)
+	var authorMu sync.Mutex
+
+	concurrency := cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
 	}
 
-	// 4. Fetch details (message, diff) for selected SHAs
-	for _, sha := range finalSelectedSHAs {
-		// Get the commit details including files and patch
-		commit, _, getErr := ghc.client.Repositories.GetCommit(ghc.ctx, owner, repoName, sha, &github.ListOptions{})
-		if getErr != nil {
-			log.Printf("Error getting commit details for %s (SHA: %s): %v. Skipping this commit.", repoName, sha, getErr)
-			continue
-		}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
 
-		var message string
-		if commit.Commit != nil && commit.Commit.Message != nil {
-			message = *commit.Commit.Message
-		}
+	for i, repoName := range repoNames {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, repoName string) {
+			defer wg.Done()
+			defer func() { <-sem }()
 
-		var diffSnippet strings.Builder
-		currentLines := 0
-		if commit.Files != nil {
-			for _, file := range commit.Files {
-				if file.GetPatch() != "" { // Patch contains the diff
-					if diffLinesLimit > 0 { // Only process if limit is positive
-						patchLines := strings.Split(file.GetPatch(), "\n")
-						for _, line := range patchLines {
-							if currentLines >= diffLinesLimit {
-								diffSnippet.WriteString("\n... (diff truncated due to line limit)\n")
-								goto EndDiffProcessing // Break out of nested loops
-							}
-							diffSnippet.WriteString(line)
-							diffSnippet.WriteString("\n")
-							currentLines++
-						}
-						if currentLines < diffLinesLimit { // Add separator if not truncated yet and more files might come
-							diffSnippet.WriteString("---\n") // Separator between file diffs
-						}
-					} else { // No line limit, take full patch for this file
-						diffSnippet.WriteString(file.GetPatch())
-						diffSnippet.WriteString("\n---\n")
-					}
+			rs, authorScores := analyzeOneRepo(cfg, scmClient, evaluator, cache, localAnalyzer, repoName, i, len(repoNames))
+			results[i] = rs
+
+			if len(authorScores) > 0 {
+				authorMu.Lock()
+				for _, as := range authorScores {
+					authorLineCounts[as.Author] += as.LineCount
 				}
+				authorMu.Unlock()
 			}
-		}
-	EndDiffProcessing:
-
-		analyzedCommits = append(analyzedCommits, CommitInfo{
-			SHA:     sha,
-			Message: message,
-			Diff:    strings.TrimSuffix(diffSnippet.String(), "\n---\n"), // Clean up trailing separator
-		})
+		}(i, repoName)
 	}
-	log.Printf("Fetched %d commit details for analysis for repo %s/%s", len(analyzedCommits), owner, repoName)
-	return totalCommits, analyzedCommits, nil
-}
-
-func parseFlags() *Config {
-	orgName := flag.String("org", "", "GitHub Organization name (required)")
-	token := flag.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub Personal Access Token (optional, defaults to GITHUB_TOKEN env var)")
+	wg.Wait()
 
-	flag.Parse()
-
-	if *orgName == "" {
-		log.Println("Error: Organization name is required.")
-		flag.Usage()
-		os.Exit(1)
-	}
-
-	return &Config{
-		Organization: *orgName,
-		Token:        *token,
-	}
+	return results, authorLineCounts
 }
 
-const (
-	numRandomCommitsToAnalyze = 5   // Number of random commits to analyze per repo
-	diffLinesLimit            = 100 // Max lines of diff per commit file for analysis
-	// Weights for overall score calculation
-	commitCountWeight     = 0.2
-	technicalScoreWeight  = 0.4
-	messageScoreWeight    = 0.4
-	maxCommitCountForNorm = 1000 // For normalizing commit count score (cap at this)
-)
-
-func main() {
-	cfg := parseFlags()
-
-	geminiAPIKey := os.Getenv("GEMINI_API_KEY")
-	if geminiAPIKey == "" {
-		log.Fatal("Error: GEMINI_API_KEY environment variable not set.")
+// analyzeOneRepo は単一のリポジトリを分析し、そのRepoScoreと(-by-author時は)著者別の行数統計を返す。
+// analyzeReposConcurrently から複数ゴルーチンで同時に呼ばれるため、repoName 以外の引数はすべて読み取り専用で扱う。
+func analyzeOneRepo(cfg *Config, scmClient SCMClient, evaluator Evaluator, cache *Cache, localAnalyzer *LocalGitAnalyzer, repoName string, index, total int) (RepoScore, []AuthorScore) {
+	logger.Info("analyzing repository", "repo", repoName, "progress", fmt.Sprintf("%d/%d", index+1, total))
+	repoFullName := fmt.Sprintf("%s/%s", cfg.Organization, repoName)
+
+	var totalRepoCommits int
+	var sampledCommits []CommitInfo
+	var authorScores []AuthorScore
+	var err error
+
+	knownEmpty := false
+	if cache != nil {
+		if cachedTotal, ok, cacheErr := cache.GetTotalCommits(repoFullName, totalCommitsCacheTTL); cacheErr == nil && ok && cachedTotal == 0 {
+			// 直近のキャッシュで0コミットと分かっているリポジトリは、クローン/APIコールをスキップする
+			knownEmpty = true
+		}
 	}
 
-	ghClient := NewGitHubClient(cfg.Token)
-	geminiClient, err := NewGeminiClient(geminiAPIKey)
-	if err != nil {
-		log.Fatalf("Error creating Gemini client: %v\n", err)
+	if !knownEmpty {
+		if localAnalyzer != nil {
+			totalRepoCommits, sampledCommits, authorScores, err = localAnalyzer.Analyze(cloneURLForRepo(cfg, repoName), repoName, cloneAuthForConfig(cfg), numRandomCommitsToAnalyze, diffLinesLimit, cfg.ByAuthor)
+		} else {
+			totalRepoCommits, sampledCommits, err = scmClient.GetCommitAnalysisData(cfg.Organization, repoName, numRandomCommitsToAnalyze, diffLinesLimit)
+		}
+		if err == nil && cache != nil {
+			if cacheErr := cache.PutTotalCommits(repoFullName, totalRepoCommits); cacheErr != nil {
+				logger.Warn("failed to cache commit count", "repo", repoFullName, "err", cacheErr)
+			}
+		}
 	}
-
-	log.Printf("Fetching repositories for organization: %s\n", cfg.Organization)
-	repos, err := ghClient.GetOrganizationRepos(cfg.Organization)
 	if err != nil {
-		log.Fatalf("Error: %v\n", err)
+		logger.Warn("failed to get commit analysis data, skipping this repo", "repo", repoName, "err", err)
+		return RepoScore{Name: repoName, CommitCount: -1}, authorScores // Mark as errored
 	}
 
-	if len(repos) == 0 {
-		log.Printf("No repositories found for organization %s.\n", cfg.Organization)
-		return
+	if totalRepoCommits == 0 {
+		logger.Info("repository has 0 commits, skipping LLM analysis", "repo", repoName)
+		return RepoScore{Name: repoName, CommitCount: 0, TechnicalScore: 0, MessageScore: 0, OverallScore: 0, AnalyzedCommitsCount: 0}, authorScores
 	}
 
-	log.Printf("Found %d repositories. Analyzing each repository...\n", len(repos))
-
-	var repoScores []RepoScore
-
-	for i, repo := range repos {
-		repoName := repo.GetName()
-		if repoName == "" {
-			log.Printf("Skipping repository with no name (index %d)", i)
-			continue
-		}
-		log.Printf("Analyzing repository: %s (%d/%d)", repoName, i+1, len(repos))
-
-		totalRepoCommits, sampledCommits, err := ghClient.GetRepositoryCommitAnalysisData(cfg.Organization, repoName, numRandomCommitsToAnalyze, diffLinesLimit)
-		if err != nil {
-			log.Printf("Error getting commit analysis data for %s: %v. Skipping this repo.", repoName, err)
-			repoScores = append(repoScores, RepoScore{Name: repoName, CommitCount: -1}) // Mark as errored
-			continue
-		}
-
-		if totalRepoCommits == 0 {
-			log.Printf("Repository %s has 0 commits. Skipping Gemini analysis.", repoName)
-			repoScores = append(repoScores, RepoScore{Name: repoName, CommitCount: 0, TechnicalScore: 0, MessageScore: 0, OverallScore: 0, AnalyzedCommitsCount: 0})
-			continue
-		}
-
-		geminiEval, err := geminiClient.AnalyzeCommitsWithGemini(repoName, totalRepoCommits, sampledCommits)
-		if err != nil {
-			log.Printf("Error analyzing commits with Gemini for %s: %v. Assigning default scores.", repoName, err)
-			// Assign default/error scores if Gemini fails
-			repoScores = append(repoScores, RepoScore{
-				Name:                 repoName,
-				CommitCount:          totalRepoCommits,
-				TechnicalScore:       0, // Or some error indicator like -1
-				MessageScore:         0, // Or some error indicator like -1
-				OverallScore:         0,
-				AnalyzedCommitsCount: len(sampledCommits),
-				SampledCommits:       sampledCommits,
-			})
-			continue
-		}
-
-		// Normalize commit count score (0-10)
-		normalizedCommitCount := float64(totalRepoCommits)
-		if normalizedCommitCount > float64(maxCommitCountForNorm) {
-			normalizedCommitCount = float64(maxCommitCountForNorm)
-		}
-		commitScore := (normalizedCommitCount / float64(maxCommitCountForNorm)) * 10.0
-
-		// Calculate overall score
-		overallScore := (commitScore * commitCountWeight) +
-			(float64(geminiEval.TechnicalSophistication) * technicalScoreWeight) +
-			(float64(geminiEval.MessageAppropriateness) * messageScoreWeight)
-
-		repoScores = append(repoScores, RepoScore{
+	evalScore, err := evaluator.AnalyzeCommits(repoFullName, totalRepoCommits, sampledCommits)
+	if err != nil {
+		logger.Warn("failed to analyze commits with LLM, assigning default scores", "repo", repoName, "err", err)
+		return RepoScore{
 			Name:                 repoName,
 			CommitCount:          totalRepoCommits,
-			TechnicalScore:       geminiEval.TechnicalSophistication,
-			MessageScore:         geminiEval.MessageAppropriateness,
-			OverallScore:         overallScore,
+			TechnicalScore:       0,
+			MessageScore:         0,
+			OverallScore:         0,
 			AnalyzedCommitsCount: len(sampledCommits),
 			SampledCommits:       sampledCommits,
-		})
-		// Optional: Add a small delay to avoid hitting API rate limits too quickly if many repos
-		// time.Sleep(1 * time.Second)
-	}
-
-	// Sort repositories by OverallScore in descending order
-	sort.SliceStable(repoScores, func(i, j int) bool {
-		return repoScores[i].OverallScore > repoScores[j].OverallScore
-	})
+		}, authorScores
+	}
+
+	// Normalize commit count score (0-10)
+	normalizedCommitCount := float64(totalRepoCommits)
+	if normalizedCommitCount > float64(maxCommitCountForNorm) {
+		normalizedCommitCount = float64(maxCommitCountForNorm)
+	}
+	commitScore := (normalizedCommitCount / float64(maxCommitCountForNorm)) * 10.0
+
+	sigRatio := signatureRatio(sampledCommits)
+
+	// Calculate overall score
+	overallScore := (commitScore * commitCountWeight) +
+		(float64(evalScore.TechnicalSophistication) * technicalScoreWeight) +
+		(float64(evalScore.MessageAppropriateness) * messageScoreWeight) +
+		(sigRatio * 10.0 * signatureScoreWeight)
+
+	return RepoScore{
+		Name:                 repoName,
+		CommitCount:          totalRepoCommits,
+		TechnicalScore:       evalScore.TechnicalSophistication,
+		MessageScore:         evalScore.MessageAppropriateness,
+		SignatureRatio:       sigRatio,
+		OverallScore:         overallScore,
+		AnalyzedCommitsCount: len(sampledCommits),
+		SampledCommits:       sampledCommits,
+	}, authorScores
+}
 
-	// Print results
-	fmt.Println(strings.Repeat("=", 120))
-	fmt.Printf("%-40s | %-10s | %-10s | %-10s | %-10s | %-15s\n", "Repository", "Commits", "Tech Score", "Msg Score", "Overall", "Analyzed Smpls")
-	fmt.Println(strings.Repeat("-", 120))
-	for _, rs := range repoScores {
-		if rs.CommitCount == -1 { // Error case
-			fmt.Printf("%-40s | %-10s | %-10s | %-10s | %-10s | %-15s\n", rs.Name, "ERROR", "N/A", "N/A", "N/A", "N/A")
-			continue
-		}
-		fmt.Printf("%-40s | %-10d | %-10d | %-10d | %-10.2f | %-15d\n",
-			rs.Name, rs.CommitCount, rs.TechnicalScore, rs.MessageScore, rs.OverallScore, rs.AnalyzedCommitsCount)
+// printAuthorLeaderboard は -mode=local -by-author で集計した著者別の現存行数を降順で表示する
+func printAuthorLeaderboard(authorLineCounts map[string]int) {
+	scores := make([]AuthorScore, 0, len(authorLineCounts))
+	for author, count := range authorLineCounts {
+		scores = append(scores, AuthorScore{Author: author, LineCount: count})
 	}
-	fmt.Println(strings.Repeat("=", 120))
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].LineCount > scores[j].LineCount })
 
-	// Optional: Print details of sampled commits for top N repositories
-	// printTopNRepoSamples(repoScores, 3)
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("%-40s | %-15s\n", "Author", "Surviving Lines")
+	fmt.Println(strings.Repeat("-", 60))
+	for _, as := range scores {
+		fmt.Printf("%-40s | %-15d\n", as.Author, as.LineCount)
+	}
+	fmt.Println(strings.Repeat("=", 60))
 }
 
 // func printTopNRepoSamples(scores []RepoScore, topN int) {
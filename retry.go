@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"golang.org/x/time/rate"
+)
+
+// githubLimiter/giteaLimiter/llmLimiter はそれぞれのAPI呼び出しレートを抑えるトークンバケット。
+// GitHubのセカンダリレート制限は概ね1秒あたり数リクエスト程度で引っかかるため控えめな値にしている。
+// LLM側もプロバイダ各社のQPS上限を超えないよう、種類を問わず同じバケットを共有させる。
+var (
+	githubLimiter = rate.NewLimiter(rate.Limit(8), 8)
+	giteaLimiter  = rate.NewLimiter(rate.Limit(8), 8)
+	llmLimiter    = rate.NewLimiter(rate.Limit(2), 2)
+)
+
+// maxRetryAttempts はリトライ可能なエラーに対して試行する最大回数(初回呼び出しを含む)
+const maxRetryAttempts = 5
+
+// withRetry は limiter でレートを制限しつつ fn を実行し、リトライ可能なエラーが返った場合は
+// 指数バックオフ+ジッターで再試行する。operation はログに出すラベル(例: "github.GetCommit")。
+// fields は呼び出し元のrepo/shaなど、ログに添えたい追加のキー・バリューペア(slog形式)。
+func withRetry(ctx context.Context, limiter *rate.Limiter, operation string, fields []any, fn func(attempt int) error) error {
+	log := logger.With(fields...)
+
+	var lastErr error
+	for attempt := 1; attempt <= maxRetryAttempts; attempt++ {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("rate limiter wait failed for %s: %w", operation, err)
+			}
+		}
+
+		start := time.Now()
+		err := fn(attempt)
+		latencyMs := time.Since(start).Milliseconds()
+
+		if err == nil {
+			log.Debug("operation succeeded", "op", operation, "attempt", attempt, "latency_ms", latencyMs)
+			return nil
+		}
+		lastErr = err
+
+		delay, retryable := retryDelay(err, attempt)
+		if !retryable || attempt == maxRetryAttempts {
+			log.Warn("operation failed", "op", operation, "attempt", attempt, "latency_ms", latencyMs, "err", err)
+			break
+		}
+		log.Warn("operation failed, retrying", "op", operation, "attempt", attempt, "latency_ms", latencyMs, "retry_in_ms", delay.Milliseconds(), "err", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return lastErr
+}
+
+// retryDelay はエラーの種類から次の再試行までの待機時間と、そもそもリトライすべきかを判定する。
+// GitHubのセカンダリレート制限(Retry-After/X-RateLimit-Reset)を最優先で尊重し、
+// それ以外の429/5xx相当のエラーには指数バックオフ+ジッターを用いる。
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		if d := time.Until(rateLimitErr.Rate.Reset.Time); d > 0 {
+			return d, true
+		}
+		return exponentialBackoff(attempt), true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return exponentialBackoff(attempt), true
+	}
+
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil {
+		switch {
+		// 403のレート制限はgo-githubがRateLimitError/AbuseRateLimitErrorとして型付けして返すため、
+		// ここに来る403は権限不足などの恒久的なエラーとみなしリトライしない。
+		case ghErr.Response.StatusCode == http.StatusTooManyRequests:
+			return exponentialBackoff(attempt), true
+		case ghErr.Response.StatusCode >= 500:
+			return exponentialBackoff(attempt), true
+		default:
+			return 0, false
+		}
+	}
+
+	if isRetryableLLMError(err) {
+		return exponentialBackoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// exponentialBackoff は attempt 回目の待機時間を 2^attempt 秒を基準に、ジッターを加えて計算する
+func exponentialBackoff(attempt int) time.Duration {
+	base := time.Duration(1<<uint(attempt)) * time.Second
+	if base > 30*time.Second {
+		base = 30 * time.Second
+	}
+	jitter := time.Duration(rand.Int63n(int64(base)/2 + 1))
+	return base/2 + jitter
+}
+
+// isRetryableLLMError はLLM API呼び出しのエラーメッセージから429(レート制限)や5xx(サーバエラー)を検出する。
+// OpenAI/Anthropic/Ollamaは生HTTPで呼んでおり型付きエラーを持たないため、文字列判定で代用する。
+func isRetryableLLMError(err error) bool {
+	msg := err.Error()
+	for _, marker := range []string{"429", "RESOURCE_EXHAUSTED", "status 500", "status 502", "status 503", "status 504", "status 529"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/rand" // For random commit selection
+	"fmt"
+	"math/big" // For random commit selection
+)
+
+// SCMClient は分析対象のSCM(ソースコード管理サービス)に対する操作を抽象化するインターフェース。
+// GitHubに加えてGitea/Forgejoなど自己ホスト型のサービスも同じ呼び出し側コードで扱えるようにする。
+type SCMClient interface {
+	// ListRepos は指定されたOrganization(Giteaの場合はOwner)配下のリポジトリ名一覧を取得する
+	ListRepos(org string) ([]string, error)
+	// CountCommits は指定されたリポジトリのデフォルトブランチのコミット総数を取得する
+	CountCommits(owner, repoName string) (int, error)
+	// GetCommitAnalysisData はコミット総数と、分析対象としてランダムに選んだコミットの詳細(メッセージ+diffスニペット)を取得する
+	GetCommitAnalysisData(owner, repoName string, numRandomCommitsToAnalyze, diffLinesLimit int) (totalCommits int, analyzedCommits []CommitInfo, err error)
+}
+
+// NewSCMClient は設定の -scm フラグに応じてGitHubまたはGitea/ForgejoのSCMClient実装を生成する
+func NewSCMClient(cfg *Config) (SCMClient, error) {
+	switch cfg.SCM {
+	case "", "github":
+		return NewGitHubClient(cfg.Token), nil
+	case "gitea":
+		return NewGiteaClient(cfg.BaseURL, cfg.Token)
+	default:
+		return nil, fmt.Errorf("unsupported -scm value %q (expected \"github\" or \"gitea\")", cfg.SCM)
+	}
+}
+
+// selectNRandomSHAs は all から最大 n 個のユニークなSHAをランダムに選ぶ。
+// crypto/rand を用いたFisher-Yatesシャッフルにより、特定のコミットに偏らないようにする。
+// GitHub/Gitea双方のバックエンドで共通して利用する。
+func selectNRandomSHAs(all []string, n int) []string {
+	if n > len(all) {
+		n = len(all)
+	}
+	indices := make([]int, len(all))
+	for i := range indices {
+		indices[i] = i
+	}
+	for i := len(indices) - 1; i > 0; i-- {
+		jBig, _ := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		j := int(jBig.Int64())
+		indices[i], indices[j] = indices[j], indices[i]
+	}
+	selected := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		selected = append(selected, all[indices[i]])
+	}
+	return selected
+}
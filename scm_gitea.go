@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+// GiteaClient はGitea/Forgejo APIのクライアントをラップし、SCMClientインターフェースを満たす。
+// セルフホスト型のインスタンスで運用しているチームが、フォークなしでこのツールを使えるようにする。
+type GiteaClient struct {
+	client *gitea.Client
+}
+
+// NewGiteaClient は新しいGitea/Forgejoクライアントを作成する
+// baseURL はセルフホストインスタンスのURL(例: https://git.example.com)を指定する
+func NewGiteaClient(baseURL, token string) (*GiteaClient, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("-base-url is required when -scm=gitea")
+	}
+	var opts []gitea.ClientOption
+	if token != "" {
+		opts = append(opts, gitea.SetToken(token))
+	}
+	client, err := gitea.NewClient(baseURL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gitea client for %s: %w", baseURL, err)
+	}
+	return &GiteaClient{client: client}, nil
+}
+
+// ListRepos は指定されたOwner(ユーザーまたはOrganization)配下のリポジトリ名一覧を取得する
+func (gic *GiteaClient) ListRepos(owner string) ([]string, error) {
+	var names []string
+	opt := gitea.ListOrgReposOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	for {
+		var repos []*gitea.Repository
+		var resp *gitea.Response
+		err := withRetry(context.Background(), giteaLimiter, "gitea.ListOrgRepos", []any{"owner", owner}, func(attempt int) error {
+			var listErr error
+			repos, resp, listErr = gic.client.ListOrgRepos(owner, opt)
+			return listErr
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories for owner %s: %w", owner, err)
+		}
+		for _, r := range repos {
+			names = append(names, r.Name)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return names, nil
+}
+
+// CountCommits は指定されたリポジトリのデフォルトブランチのコミット総数を取得する
+func (gic *GiteaClient) CountCommits(owner, repoName string) (int, error) {
+	var count int
+	opt := gitea.ListCommitOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	for {
+		var commits []*gitea.Commit
+		var resp *gitea.Response
+		err := withRetry(context.Background(), giteaLimiter, "gitea.ListRepoCommits.count", []any{"repo", repoName}, func(attempt int) error {
+			var listErr error
+			commits, resp, listErr = gic.client.ListRepoCommits(owner, repoName, opt)
+			return listErr
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to list commits for %s/%s: %w", owner, repoName, err)
+		}
+		count += len(commits)
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return count, nil
+}
+
+// GetCommitAnalysisData fetches total commit count and details of N random commits (message + diff snippet) from Gitea/Forgejo.
+func (gic *GiteaClient) GetCommitAnalysisData(owner, repoName string, numRandomCommitsToAnalyze, diffLinesLimit int) (totalCommits int, analyzedCommits []CommitInfo, err error) {
+	totalCommits, err = gic.CountCommits(owner, repoName)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error counting all commits for %s/%s: %w", owner, repoName, err)
+	}
+	if totalCommits == 0 {
+		return 0, []CommitInfo{}, nil
+	}
+
+	var allCommitSHAs []string
+	opt := gitea.ListCommitOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	for {
+		var commitsPage []*gitea.Commit
+		var resp *gitea.Response
+		listErr := withRetry(context.Background(), giteaLimiter, "gitea.ListRepoCommits.shas", []any{"repo", repoName}, func(attempt int) error {
+			var err error
+			commitsPage, resp, err = gic.client.ListRepoCommits(owner, repoName, opt)
+			return err
+		})
+		if listErr != nil {
+			logger.Warn("failed to list commit SHAs, proceeding with no sampled commits", "repo", repoName, "total_commits", totalCommits, "err", listErr)
+			return totalCommits, []CommitInfo{}, nil
+		}
+		for _, c := range commitsPage {
+			allCommitSHAs = append(allCommitSHAs, c.SHA)
+		}
+		if resp == nil || resp.NextPage == 0 || len(allCommitSHAs) >= totalCommits {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if len(allCommitSHAs) == 0 {
+		logger.Warn("no commit SHAs found despite nonzero total commits, proceeding with no sampled commits", "repo", repoName, "total_commits", totalCommits)
+		return totalCommits, []CommitInfo{}, nil
+	}
+
+	finalSelectedSHAs := selectNRandomSHAs(allCommitSHAs, numRandomCommitsToAnalyze)
+
+	for _, sha := range finalSelectedSHAs {
+		var commit *gitea.Commit
+		getErr := withRetry(context.Background(), giteaLimiter, "gitea.GetSingleCommit", []any{"repo", repoName, "sha", sha}, func(attempt int) error {
+			var err error
+			commit, _, err = gic.client.GetSingleCommit(owner, repoName, sha)
+			return err
+		})
+		if getErr != nil {
+			logger.Warn("failed to get commit details, skipping this commit", "repo", repoName, "sha", sha, "err", getErr)
+			continue
+		}
+
+		var message string
+		if commit.RepoCommit != nil {
+			message = commit.RepoCommit.Message
+		}
+
+		var verified bool
+		var sigReason string
+		if commit.RepoCommit != nil && commit.RepoCommit.Verification != nil {
+			verified = commit.RepoCommit.Verification.Verified
+			sigReason = commit.RepoCommit.Verification.Reason
+		}
+
+		// CommitAffectedFiles (commit.Files) only carries the filename, not a per-file patch
+		// (unlike go-github's RepositoryCommit.Files), so the diff snippet is built from the
+		// raw unified diff returned by the dedicated /git/commits/{sha}.diff endpoint instead.
+		var rawDiff []byte
+		diffErr := withRetry(context.Background(), giteaLimiter, "gitea.GetCommitDiff", []any{"repo", repoName, "sha", sha}, func(attempt int) error {
+			var err error
+			rawDiff, _, err = gic.client.GetCommitDiff(owner, repoName, sha)
+			return err
+		})
+		if diffErr != nil {
+			logger.Warn("failed to get commit diff, proceeding with no diff", "repo", repoName, "sha", sha, "err", diffErr)
+			rawDiff = nil
+		}
+
+		diff := truncateDiffLines(string(rawDiff), diffLinesLimit)
+
+		analyzedCommits = append(analyzedCommits, CommitInfo{
+			SHA:             sha,
+			Message:         message,
+			Diff:            diff,
+			Verified:        verified,
+			SignatureReason: sigReason,
+		})
+	}
+	logger.Debug("fetched commit details for analysis", "repo", fmt.Sprintf("%s/%s", owner, repoName), "count", len(analyzedCommits))
+	return totalCommits, analyzedCommits, nil
+}
+
+// truncateDiffLines は raw の先頭 limit 行だけを残す。limit が0以下なら制限なしでそのまま返す。
+func truncateDiffLines(raw string, limit int) string {
+	if limit <= 0 || raw == "" {
+		return raw
+	}
+	lines := strings.Split(raw, "\n")
+	if len(lines) <= limit {
+		return raw
+	}
+	return strings.Join(lines[:limit], "\n") + "\n... (diff truncated due to line limit)\n"
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"golang.org/x/oauth2"
+)
+
+// GitHubClient はGitHub APIのクライアントをラップし、SCMClientインターフェースを満たす
+type GitHubClient struct {
+	client *github.Client
+	ctx    context.Context
+}
+
+// NewGitHubClient は新しいGitHubクライアントを作成
+func NewGitHubClient(token string) *GitHubClient {
+	ctx := context.Background()
+	var ghClient *github.Client
+
+	if token != "" {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+		tc := oauth2.NewClient(ctx, ts)
+		ghClient = github.NewClient(tc)
+	} else {
+		ghClient = github.NewClient(nil)
+	}
+
+	return &GitHubClient{
+		client: ghClient,
+		ctx:    ctx,
+	}
+}
+
+// ListRepos は指定されたOrganizationのリポジトリ名一覧を取得
+func (ghc *GitHubClient) ListRepos(orgName string) ([]string, error) {
+	var names []string
+	opt := &github.RepositoryListByOrgOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		var repos []*github.Repository
+		var resp *github.Response
+		err := withRetry(ghc.ctx, githubLimiter, "github.ListByOrg", []any{"org", orgName}, func(attempt int) error {
+			var listErr error
+			repos, resp, listErr = ghc.client.Repositories.ListByOrg(ghc.ctx, orgName, opt)
+			return listErr
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories for organization %s: %w", orgName, err)
+		}
+		for _, r := range repos {
+			if r.GetName() != "" {
+				names = append(names, r.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return names, nil
+}
+
+// CountCommits は指定されたリポジトリのデフォルトブランチのコミット総数を取得
+// 注意: GitHub API v3では全コミット数を直接取得する簡単な方法は提供されていないため、
+// ページネーションを利用して全コミットを数える。
+func (ghc *GitHubClient) CountCommits(owner, repoName string) (int, error) {
+	var commitCount int
+	opt := &github.CommitsListOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		var commits []*github.RepositoryCommit
+		var resp *github.Response
+		err := withRetry(ghc.ctx, githubLimiter, "github.ListCommits.count", []any{"repo", repoName}, func(attempt int) error {
+			var listErr error
+			commits, resp, listErr = ghc.client.Repositories.ListCommits(ghc.ctx, owner, repoName, opt)
+			return listErr
+		})
+		if err != nil {
+			if resp != nil && resp.StatusCode == 409 { // Conflict, e.g. empty repository
+				return 0, nil
+			}
+			return 0, fmt.Errorf("failed to list commits for %s/%s during full count: %w", owner, repoName, err)
+		}
+		commitCount += len(commits)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return commitCount, nil
+}
+
+// GetCommitAnalysisData fetches total commit count and details of N random commits (message + diff snippet).
+// numRandomCommitsToAnalyze: 収集するランダムコミットの数
+// diffLinesLimit: 各コミットのdiffから取得する最大行数
+func (ghc *GitHubClient) GetCommitAnalysisData(owner, repoName string, numRandomCommitsToAnalyze, diffLinesLimit int) (totalCommits int, analyzedCommits []CommitInfo, err error) {
+	// 1. Get total commit count
+	totalCommits, err = ghc.CountCommits(owner, repoName)
+	if err != nil {
+		if totalCommits == 0 && strings.Contains(err.Error(), "409") {
+			// 空リポジトリは0件として扱う
+		} else {
+			return 0, nil, fmt.Errorf("error counting all commits for %s/%s: %w", owner, repoName, err)
+		}
+	}
+	if totalCommits == 0 {
+		return 0, []CommitInfo{}, nil
+	}
+
+	// 2. Get all commit SHAs (can be slow for very large repos)
+	var allCommitSHAs []string
+	listOpt := &github.CommitsListOptions{
+		ListOptions: github.ListOptions{PerPage: 100}, // Fetch 100 SHAs per page
+	}
+	for {
+		var commitsPage []*github.RepositoryCommit
+		var resp *github.Response
+		listErr := withRetry(ghc.ctx, githubLimiter, "github.ListCommits.shas", []any{"repo", repoName}, func(attempt int) error {
+			var err error
+			commitsPage, resp, err = ghc.client.Repositories.ListCommits(ghc.ctx, owner, repoName, listOpt)
+			return err
+		})
+		if listErr != nil {
+			// If we fail to list commits here, but got a total count, return count with empty analysis.
+			logger.Warn("failed to list commit SHAs, proceeding with no sampled commits", "repo", repoName, "total_commits", totalCommits, "err", listErr)
+			return totalCommits, []CommitInfo{}, nil
+		}
+		for _, c := range commitsPage {
+			if c.SHA != nil {
+				allCommitSHAs = append(allCommitSHAs, *c.SHA)
+			}
+		}
+		if resp.NextPage == 0 || len(allCommitSHAs) >= totalCommits {
+			break
+		}
+		listOpt.Page = resp.NextPage
+		if len(allCommitSHAs) > 500 && numRandomCommitsToAnalyze <= 10 { // Optimization: if repo is huge, don't fetch all SHAs if we only need a few
+			logger.Debug("stopping SHA fetch early, already have enough samples", "repo", repoName, "fetched", len(allCommitSHAs), "needed", numRandomCommitsToAnalyze)
+			break
+		}
+	}
+
+	if len(allCommitSHAs) == 0 {
+		logger.Warn("no commit SHAs found despite nonzero total commits, proceeding with no sampled commits", "repo", repoName, "total_commits", totalCommits)
+		return totalCommits, []CommitInfo{}, nil
+	}
+
+	// 3. Select N random unique commit SHAs
+	finalSelectedSHAs := selectNRandomSHAs(allCommitSHAs, numRandomCommitsToAnalyze)
+
+	// 4. Fetch details (message, diff) for selected SHAs
+	for _, sha := range finalSelectedSHAs {
+		// Get the commit details including files and patch
+		var commit *github.RepositoryCommit
+		getErr := withRetry(ghc.ctx, githubLimiter, "github.GetCommit", []any{"repo", repoName, "sha", sha}, func(attempt int) error {
+			var err error
+			commit, _, err = ghc.client.Repositories.GetCommit(ghc.ctx, owner, repoName, sha, &github.ListOptions{})
+			return err
+		})
+		if getErr != nil {
+			logger.Warn("failed to get commit details, skipping this commit", "repo", repoName, "sha", sha, "err", getErr)
+			continue
+		}
+
+		var message string
+		if commit.Commit != nil && commit.Commit.Message != nil {
+			message = *commit.Commit.Message
+		}
+
+		var verified bool
+		var sigReason string
+		if commit.Commit != nil && commit.Commit.Verification != nil {
+			verified = commit.Commit.Verification.GetVerified()
+			sigReason = commit.Commit.Verification.GetReason()
+		}
+
+		var diffSnippet strings.Builder
+		currentLines := 0
+		if commit.Files != nil {
+			for _, file := range commit.Files {
+				if file.GetPatch() != "" { // Patch contains the diff
+					if diffLinesLimit > 0 { // Only process if limit is positive
+						patchLines := strings.Split(file.GetPatch(), "\n")
+						for _, line := range patchLines {
+							if currentLines >= diffLinesLimit {
+								diffSnippet.WriteString("\n... (diff truncated due to line limit)\n")
+								goto EndDiffProcessing // Break out of nested loops
+							}
+							diffSnippet.WriteString(line)
+							diffSnippet.WriteString("\n")
+							currentLines++
+						}
+						if currentLines < diffLinesLimit { // Add separator if not truncated yet and more files might come
+							diffSnippet.WriteString("---\n") // Separator between file diffs
+						}
+					} else { // No line limit, take full patch for this file
+						diffSnippet.WriteString(file.GetPatch())
+						diffSnippet.WriteString("\n---\n")
+					}
+				}
+			}
+		}
+	EndDiffProcessing:
+
+		analyzedCommits = append(analyzedCommits, CommitInfo{
+			SHA:             sha,
+			Message:         message,
+			Diff:            strings.TrimSuffix(diffSnippet.String(), "\n---\n"), // Clean up trailing separator
+			Verified:        verified,
+			SignatureReason: sigReason,
+		})
+	}
+	logger.Debug("fetched commit details for analysis", "repo", fmt.Sprintf("%s/%s", owner, repoName), "count", len(analyzedCommits))
+	return totalCommits, analyzedCommits, nil
+}